@@ -15,13 +15,23 @@
 package summary
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	kubeletconfigv1beta1 "k8s.io/kubernetes/pkg/kubelet/apis/config/v1beta1"
 	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
 )
 
@@ -29,12 +39,258 @@ import (
 type KubeletInterface interface {
 	// GetSummary fetches summary metrics from the given Kubelet
 	GetSummary(host string) (*stats.Summary, error)
+
+	// GetSummaryWithContext fetches summary metrics from the given Kubelet,
+	// aborting the request if ctx is cancelled or its deadline expires
+	// before the Kubelet responds.
+	GetSummaryWithContext(ctx context.Context, host string) (*stats.Summary, error)
+
+	// GetKubeletConfiguration fetches the Kubelet's running configuration from
+	// its /configz endpoint, aborting if ctx is cancelled or its deadline
+	// expires before the Kubelet responds.
+	GetKubeletConfiguration(ctx context.Context, host string) (*kubeletconfigv1beta1.KubeletConfiguration, error)
+
+	// PreferredNodeAddress resolves the host to scrape node on, honoring the
+	// NodeAddressTypePriority the client was constructed with.
+	PreferredNodeAddress(node *corev1.Node) (string, error)
+}
+
+// configzWrapper is the envelope the Kubelet's /configz endpoint wraps its
+// configuration in, e.g. `{"kubeletconfig": {...}}`.
+type configzWrapper struct {
+	ComponentConfig kubeletconfigv1beta1.KubeletConfiguration `json:"kubeletconfig"`
 }
 
 type kubeletClient struct {
 	portIsInsecure bool
 	port           uint
 	client         *http.Client
+
+	// autoDetectPort, when set, makes GetSummary consult the node's /configz
+	// endpoint to decide between the read-only port and the secure port,
+	// rather than always using port/portIsInsecure.
+	autoDetectPort bool
+
+	nodePortsMu sync.Mutex
+	nodePorts   map[string]nodePort
+
+	// addressTypePriority is the order in which node addresses are
+	// preferred when PreferredNodeAddress resolves a host to scrape.
+	addressTypePriority []corev1.NodeAddressType
+
+	// sourceType selects which Kubelet endpoint GetSummary scrapes.
+	sourceType SourceType
+
+	unsupportedResourceMetricsMu sync.Mutex
+	unsupportedResourceMetrics   map[string]bool
+
+	// requestTimeout bounds how long a single node's scrape may take. Zero
+	// means no client-imposed timeout beyond ctx's own deadline.
+	requestTimeout time.Duration
+	// scrapeSem bounds how many scrapes may be in flight at once. Nil means
+	// unbounded.
+	scrapeSem chan struct{}
+}
+
+var (
+	scrapeLatencySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "metrics_server",
+		Subsystem: "kubelet_summary",
+		Name:      "scrape_duration_seconds",
+		Help:      "Duration in seconds of scrape requests to the Kubelet, by node.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"node"})
+	scrapeErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "metrics_server",
+		Subsystem: "kubelet_summary",
+		Name:      "scrape_errors_total",
+		Help:      "Total number of failed scrape requests to the Kubelet, by node.",
+	}, []string{"node"})
+)
+
+func init() {
+	prometheus.MustRegister(scrapeLatencySeconds, scrapeErrorsTotal)
+}
+
+// SourceType selects which Kubelet endpoint a client scrapes for node and
+// pod resource usage.
+type SourceType int
+
+const (
+	// SummaryAPI scrapes the Kubelet's /stats/summary endpoint.
+	SummaryAPI SourceType = iota
+	// ResourceMetricsAPI scrapes the Kubelet's lighter-weight, stable
+	// /metrics/resource Prometheus endpoint, falling back to SummaryAPI for
+	// nodes that don't serve it.
+	ResourceMetricsAPI
+)
+
+// nodePort caches the port/scheme a given node was found to be serving on.
+type nodePort struct {
+	port     uint
+	insecure bool
+}
+
+// DefaultAddressTypePriority is used to resolve a Node's scrape address when
+// NodeAddressTypePriority is left unset.
+var DefaultAddressTypePriority = []corev1.NodeAddressType{
+	corev1.NodeInternalIP,
+	corev1.NodeExternalIP,
+	corev1.NodeHostName,
+}
+
+// NodeAddress picks the first address on node matching, in order, one of
+// the address types in priority (or DefaultAddressTypePriority if priority
+// is empty). This correctly handles IPv6 literals, since the result is
+// joined with a port via net.JoinHostPort rather than naive string
+// concatenation.
+func NodeAddress(node *corev1.Node, priority []corev1.NodeAddressType) (string, error) {
+	if len(priority) == 0 {
+		priority = DefaultAddressTypePriority
+	}
+	for _, addrType := range priority {
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == addrType && addr.Address != "" {
+				return addr.Address, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("node %q has no address matching any of %v", node.Name, priority)
+}
+
+// TokenSource supplies a bearer token to authenticate requests to the
+// Kubelet, e.g. a ServiceAccount token projected into the metrics-server
+// pod.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// TokenSourceFunc adapts a plain function to a TokenSource, for callers that
+// want to supply a token inline rather than reading it from a file.
+type TokenSourceFunc func() (string, error)
+
+func (f TokenSourceFunc) Token() (string, error) {
+	return f()
+}
+
+// fileTokenSource reads a bearer token from a file, reloading it whenever it
+// is older than period so that rotated projected-volume tokens (such as
+// /var/run/secrets/kubernetes.io/serviceaccount/token) keep working without
+// requiring a restart.
+type fileTokenSource struct {
+	path   string
+	period time.Duration
+
+	mu       sync.RWMutex
+	token    string
+	lastRead time.Time
+}
+
+// NewFileTokenSource returns a TokenSource that reads the token from path,
+// re-reading it at most once per period.
+func NewFileTokenSource(path string, period time.Duration) TokenSource {
+	return &fileTokenSource{path: path, period: period}
+}
+
+func (f *fileTokenSource) Token() (string, error) {
+	f.mu.RLock()
+	fresh := f.token != "" && time.Since(f.lastRead) < f.period
+	token := f.token
+	f.mu.RUnlock()
+	if fresh {
+		return token, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.token != "" && time.Since(f.lastRead) < f.period {
+		return f.token, nil
+	}
+
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		if f.token != "" {
+			glog.V(2).Infof("unable to reload bearer token from %q, reusing previous token: %v", f.path, err)
+			return f.token, nil
+		}
+		return "", fmt.Errorf("unable to read bearer token from %q: %v", f.path, err)
+	}
+	f.token = string(content)
+	f.lastRead = time.Now()
+	return f.token, nil
+}
+
+// bearerAuthRoundTripper injects an Authorization: Bearer header sourced
+// from a TokenSource into every request.
+type bearerAuthRoundTripper struct {
+	source TokenSource
+	base   http.RoundTripper
+}
+
+func (rt *bearerAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("unable to obtain bearer token: %v", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return rt.base.RoundTrip(req)
+}
+
+// TLSConfig configures how the client validates the Kubelet's serving
+// certificate.
+type TLSConfig struct {
+	// CAFile pins the CA used to validate the Kubelet's serving certificate,
+	// e.g. a ServiceAccount's projected ca.crt.
+	CAFile string
+	// Insecure disables verification of the Kubelet's serving certificate
+	// altogether. This should only be used for testing.
+	Insecure bool
+	// ServerName overrides the name used to verify the Kubelet's serving
+	// certificate. Leave empty to verify against the address being dialed.
+	ServerName string
+	// ServerNameForHost, if set, takes priority over ServerName and is
+	// consulted per-connection so callers who track the Node object can
+	// verify the serving certificate against one of the Node's known
+	// addresses (e.g. its hostname) even though we dial by IP.
+	ServerNameForHost func(host string) string
+}
+
+func (c *TLSConfig) newTLSConfig(host string) *tls.Config {
+	serverName := c.ServerName
+	if c.ServerNameForHost != nil {
+		if name := c.ServerNameForHost(host); name != "" {
+			serverName = name
+		}
+	}
+	if serverName == "" {
+		// DialTLSContext bypasses the stdlib's usual auto-fill of
+		// ServerName from the dial address, and an empty ServerName makes
+		// crypto/tls skip hostname verification entirely - it would only
+		// chain-verify against RootCAs, so a CA-signed cert for a
+		// different node would be accepted. Default to the address being
+		// dialed so hostname verification actually happens.
+		serverName = host
+	}
+	return &tls.Config{
+		InsecureSkipVerify: c.Insecure,
+		ServerName:         serverName,
+	}
+}
+
+func (c *TLSConfig) loadRootCAs() (*x509.CertPool, error) {
+	if c.CAFile == "" {
+		return nil, nil
+	}
+	caData, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read Kubelet CA file %q: %v", c.CAFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, fmt.Errorf("unable to parse any PEM certificates from Kubelet CA file %q", c.CAFile)
+	}
+	return pool, nil
 }
 
 type ErrNotFound struct {
@@ -50,9 +306,9 @@ func IsNotFoundError(err error) bool {
 	return isNotFound
 }
 
-func (kc *kubeletClient) postRequestAndGetValue(client *http.Client, req *http.Request, value interface{}) error {
+func (kc *kubeletClient) postRequestAndGetValue(ctx context.Context, client *http.Client, req *http.Request, value interface{}) error {
 	// TODO(directxman12): support validating certs by hostname
-	response, err := client.Do(req)
+	response, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -80,13 +336,207 @@ func (kc *kubeletClient) postRequestAndGetValue(client *http.Client, req *http.R
 	return nil
 }
 
+// GetKubeletConfiguration fetches the Kubelet's running configuration from
+// its /configz endpoint and unmarshals the `{"kubeletconfig": {...}}`
+// envelope it is wrapped in.
+//
+// Newer Kubelets occasionally add fields to KubeletConfiguration that this
+// binary can't decode (e.g. `logging`); rather than fail outright when the
+// envelope doesn't decode as-is, we strip the fields listed in
+// knownDriftingConfigzFields and retry. Other fields are not stripped.
+func (kc *kubeletClient) GetKubeletConfiguration(ctx context.Context, host string) (*kubeletconfigv1beta1.KubeletConfiguration, error) {
+	url := url.URL{
+		Scheme: "https",
+		Host:   net.JoinHostPort(host, strconv.Itoa(int(kc.port))),
+		Path:   "/configz",
+	}
+	if kc.portIsInsecure {
+		url.Scheme = "http"
+	}
+
+	req, err := http.NewRequest("GET", url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := kc.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var raw json.RawMessage
+	if err := kc.postRequestAndGetValue(ctx, client, req, &raw); err != nil {
+		return nil, err
+	}
+
+	wrapper := configzWrapper{}
+	if err := json.Unmarshal(raw, &wrapper); err != nil {
+		stripped, stripErr := stripUnknownConfigzFields(raw)
+		if stripErr != nil {
+			return nil, fmt.Errorf("failed to parse /configz response, and failed to recover by stripping unknown fields: %v (original error: %v)", stripErr, err)
+		}
+		if err := json.Unmarshal(stripped, &wrapper); err != nil {
+			return nil, fmt.Errorf("failed to parse /configz response even after stripping unknown fields: %v", err)
+		}
+		glog.V(4).Infof("recovered /configz response from %s by stripping unrecognized fields", host)
+	}
+	return &wrapper.ComponentConfig, nil
+}
+
+// knownDriftingConfigzFields lists top-level kubeletconfig fields that newer
+// Kubelets have added which older vendored KubeletConfiguration types may not
+// know how to decode.
+var knownDriftingConfigzFields = []string{"logging"}
+
+// stripUnknownConfigzFields removes fields known to cause schema drift
+// between Kubelet versions from the "kubeletconfig" envelope and returns the
+// re-marshaled JSON.
+func stripUnknownConfigzFields(raw json.RawMessage) (json.RawMessage, error) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, err
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(envelope["kubeletconfig"], &config); err != nil {
+		return nil, err
+	}
+	for _, field := range knownDriftingConfigzFields {
+		delete(config, field)
+	}
+	strippedConfig, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	envelope["kubeletconfig"] = strippedConfig
+	return json.Marshal(envelope)
+}
+
+// resolveNodePort decides which port/scheme to use for a given node. When
+// autoDetectPort is disabled, it simply returns the statically configured
+// port/portIsInsecure. Otherwise, it consults (and caches) the node's
+// /configz endpoint so that nodes with readOnlyPort disabled transparently
+// fall back to the secure port. ctx bounds the /configz lookup, so a hung
+// first scrape of a node cannot block indefinitely while holding a
+// MaxConcurrentScrapes slot.
+func (kc *kubeletClient) resolveNodePort(ctx context.Context, host string) (uint, bool) {
+	if !kc.autoDetectPort {
+		return kc.port, kc.portIsInsecure
+	}
+
+	kc.nodePortsMu.Lock()
+	if cached, ok := kc.nodePorts[host]; ok {
+		kc.nodePortsMu.Unlock()
+		return cached.port, cached.insecure
+	}
+	kc.nodePortsMu.Unlock()
+
+	config, err := kc.GetKubeletConfiguration(ctx, host)
+	if err != nil {
+		// Don't cache the fallback: a transient /configz failure (e.g. a
+		// network blip on the node's first scrape) shouldn't permanently
+		// pin this node to the statically-configured port/scheme. Just
+		// use the fallback for this call and retry auto-detection next
+		// time GetSummary is called for this node.
+		glog.V(2).Infof("unable to auto-detect port for node %q via /configz, falling back to configured port %d: %v", host, kc.port, err)
+		return kc.port, kc.portIsInsecure
+	}
+
+	resolved := nodePort{port: kc.port, insecure: kc.portIsInsecure}
+	if config.ReadOnlyPort == 0 {
+		resolved = nodePort{port: uint(config.Port), insecure: false}
+	} else {
+		resolved = nodePort{port: uint(config.ReadOnlyPort), insecure: true}
+	}
+
+	kc.nodePortsMu.Lock()
+	kc.nodePorts[host] = resolved
+	kc.nodePortsMu.Unlock()
+	return resolved.port, resolved.insecure
+}
+
+// PreferredNodeAddress resolves the host to scrape node on, honoring the
+// NodeAddressTypePriority the client was constructed with.
+func (kc *kubeletClient) PreferredNodeAddress(node *corev1.Node) (string, error) {
+	return NodeAddress(node, kc.addressTypePriority)
+}
+
+// GetSummary fetches node and pod resource usage using a background
+// context. It is a thin wrapper around GetSummaryWithContext kept for
+// backward compatibility with callers that don't thread a context through.
 func (kc *kubeletClient) GetSummary(host string) (*stats.Summary, error) {
+	return kc.GetSummaryWithContext(context.Background(), host)
+}
+
+// GetSummaryWithContext fetches node and pod resource usage, honoring ctx's
+// deadline/cancellation as well as the client's RequestTimeout and
+// MaxConcurrentScrapes. When the client was constructed with SourceType
+// ResourceMetricsAPI, it scrapes the lighter /metrics/resource endpoint,
+// transparently falling back to /stats/summary (and remembering that
+// fallback for subsequent calls) for nodes that respond 404, e.g. because
+// they run an older Kubelet.
+func (kc *kubeletClient) GetSummaryWithContext(ctx context.Context, host string) (*stats.Summary, error) {
+	if kc.scrapeSem != nil {
+		select {
+		case kc.scrapeSem <- struct{}{}:
+			defer func() { <-kc.scrapeSem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if kc.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, kc.requestTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	summary, err := kc.getSummary(ctx, host)
+	scrapeLatencySeconds.WithLabelValues(host).Observe(time.Since(start).Seconds())
+	if err != nil {
+		scrapeErrorsTotal.WithLabelValues(host).Inc()
+	}
+	return summary, err
+}
+
+func (kc *kubeletClient) getSummary(ctx context.Context, host string) (*stats.Summary, error) {
+	if kc.sourceType == ResourceMetricsAPI && !kc.hasUnsupportedResourceMetrics(host) {
+		summary, err := kc.getResourceMetrics(ctx, host)
+		if err == nil {
+			return summary, nil
+		}
+		if !IsNotFoundError(err) {
+			// summary may be partially populated (e.g. when the Kubelet's
+			// scrape_error gauge flagged stale data) - pass it through so
+			// callers can decide whether to use it rather than discarding
+			// it outright.
+			return summary, err
+		}
+		glog.V(2).Infof("node %q does not serve /metrics/resource, falling back to /stats/summary", host)
+		kc.markUnsupportedResourceMetrics(host)
+	}
+	return kc.getStatsSummary(ctx, host)
+}
+
+func (kc *kubeletClient) hasUnsupportedResourceMetrics(host string) bool {
+	kc.unsupportedResourceMetricsMu.Lock()
+	defer kc.unsupportedResourceMetricsMu.Unlock()
+	return kc.unsupportedResourceMetrics[host]
+}
+
+func (kc *kubeletClient) markUnsupportedResourceMetrics(host string) {
+	kc.unsupportedResourceMetricsMu.Lock()
+	defer kc.unsupportedResourceMetricsMu.Unlock()
+	kc.unsupportedResourceMetrics[host] = true
+}
+
+func (kc *kubeletClient) getStatsSummary(ctx context.Context, host string) (*stats.Summary, error) {
+	port, portIsInsecure := kc.resolveNodePort(ctx, host)
 	url := url.URL{
 		Scheme: "https",
-		Host:   fmt.Sprintf("%s:%d", host, kc.port),
+		Host:   net.JoinHostPort(host, strconv.Itoa(int(port))),
 		Path:   "/stats/summary/",
 	}
-	if kc.portIsInsecure {
+	if portIsInsecure {
 		url.Scheme = "http"
 	}
 
@@ -99,17 +549,125 @@ func (kc *kubeletClient) GetSummary(host string) (*stats.Summary, error) {
 	if client == nil {
 		client = http.DefaultClient
 	}
-	err = kc.postRequestAndGetValue(client, req, summary)
+	err = kc.postRequestAndGetValue(ctx, client, req, summary)
 	return summary, err
 }
 
-func NewKubeletClient(transport http.RoundTripper, port uint, portIsInsecure bool) (KubeletInterface, error) {
+// KubeletClientConfig holds everything needed to construct a client that
+// talks to the Kubelets in a cluster.
+type KubeletClientConfig struct {
+	// Port is the port used when AutoDetectPort is disabled, or as a
+	// fallback when auto-detection fails.
+	Port uint
+	// PortIsInsecure indicates that Port above serves plain HTTP rather than
+	// HTTPS.
+	PortIsInsecure bool
+	// AutoDetectPort enables consulting each node's /configz endpoint to
+	// choose between the read-only and secure ports on a per-node basis.
+	AutoDetectPort bool
+
+	// NodeAddressTypePriority is the order in which a Node's addresses are
+	// tried when resolving the address to scrape. Defaults to
+	// DefaultAddressTypePriority (InternalIP, then ExternalIP, then
+	// HostName).
+	NodeAddressTypePriority []corev1.NodeAddressType
+
+	// SourceType selects which Kubelet endpoint to scrape. Defaults to
+	// SummaryAPI.
+	SourceType SourceType
+
+	// RequestTimeout bounds how long a single node's scrape may take, so a
+	// slow or hung Kubelet cannot stall an entire scrape cycle. Zero means
+	// no client-imposed timeout beyond the context passed to
+	// GetSummaryWithContext.
+	RequestTimeout time.Duration
+	// MaxConcurrentScrapes bounds how many node scrapes may be in flight at
+	// once. Zero means unbounded.
+	MaxConcurrentScrapes int
+
+	// Transport is the base RoundTripper requests are sent over, e.g. to
+	// configure timeouts or proxying. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// TLSConfig, if set, is used to validate the Kubelet's serving
+	// certificate. It replaces any TLS configuration Transport carries, and
+	// requires Transport to be an *http.Transport.
+	TLSConfig *TLSConfig
+
+	// TokenSource, if set, supplies a bearer token added to every request's
+	// Authorization header. Takes priority over BearerTokenFile.
+	TokenSource TokenSource
+	// BearerTokenFile, if set and TokenSource is nil, is periodically
+	// re-read to source the bearer token sent with every request, e.g.
+	// /var/run/secrets/kubernetes.io/serviceaccount/token.
+	BearerTokenFile string
+	// BearerTokenFileReloadPeriod controls how often BearerTokenFile is
+	// re-read. Defaults to 1 minute.
+	BearerTokenFileReloadPeriod time.Duration
+}
+
+func NewKubeletClient(cfg KubeletClientConfig) (KubeletInterface, error) {
+	transport := cfg.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	if cfg.TLSConfig != nil {
+		baseTransport, ok := transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("a TLSConfig was provided, but the base Transport is not an *http.Transport")
+		}
+		rootCAs, err := cfg.TLSConfig.loadRootCAs()
+		if err != nil {
+			return nil, err
+		}
+		baseTransport = baseTransport.Clone()
+		baseTransport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				host = addr
+			}
+			tlsConfig := cfg.TLSConfig.newTLSConfig(host)
+			tlsConfig.RootCAs = rootCAs
+			dialer := &net.Dialer{}
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return tls.Client(conn, tlsConfig), nil
+		}
+		transport = baseTransport
+	}
+
+	tokenSource := cfg.TokenSource
+	if tokenSource == nil && cfg.BearerTokenFile != "" {
+		period := cfg.BearerTokenFileReloadPeriod
+		if period == 0 {
+			period = time.Minute
+		}
+		tokenSource = NewFileTokenSource(cfg.BearerTokenFile, period)
+	}
+	if tokenSource != nil {
+		transport = &bearerAuthRoundTripper{source: tokenSource, base: transport}
+	}
+
 	c := &http.Client{
 		Transport: transport,
 	}
+	var scrapeSem chan struct{}
+	if cfg.MaxConcurrentScrapes > 0 {
+		scrapeSem = make(chan struct{}, cfg.MaxConcurrentScrapes)
+	}
+
 	return &kubeletClient{
-		portIsInsecure: portIsInsecure,
-		port:           port,
-		client:         c,
+		portIsInsecure:             cfg.PortIsInsecure,
+		port:                       cfg.Port,
+		client:                     c,
+		autoDetectPort:             cfg.AutoDetectPort,
+		nodePorts:                  make(map[string]nodePort),
+		addressTypePriority:        cfg.NodeAddressTypePriority,
+		sourceType:                 cfg.SourceType,
+		unsupportedResourceMetrics: make(map[string]bool),
+		requestTimeout:             cfg.RequestTimeout,
+		scrapeSem:                  scrapeSem,
 	}, nil
 }
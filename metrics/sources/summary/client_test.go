@@ -0,0 +1,135 @@
+// Copyright 2018 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeAddress(t *testing.T) {
+	cases := []struct {
+		name     string
+		node     *corev1.Node
+		priority []corev1.NodeAddressType
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "IPv6 literal preferred as InternalIP",
+			node: &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "fe80::1"},
+				{Type: corev1.NodeHostName, Address: "node-1"},
+			}}},
+			want: "fe80::1",
+		},
+		{
+			name: "zone-scoped IPv6 address is passed through untouched",
+			node: &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "fe80::1%eth0"},
+			}}},
+			want: "fe80::1%eth0",
+		},
+		{
+			name: "falls back to hostname when no higher-priority address is present",
+			node: &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "node-1.example.com"},
+			}}},
+			want: "node-1.example.com",
+		},
+		{
+			name: "custom priority order is honored",
+			node: &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: corev1.NodeHostName, Address: "node-1"},
+			}}},
+			priority: []corev1.NodeAddressType{corev1.NodeHostName, corev1.NodeInternalIP},
+			want:     "node-1",
+		},
+		{
+			name:    "no address matches any priority type",
+			node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := NodeAddress(c.node, c.priority)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("NodeAddress() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NodeAddress() returned unexpected error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("NodeAddress() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+// TestGetSummaryIPv6Host verifies that GetSummary builds a valid URL for an
+// IPv6 literal host, i.e. it uses net.JoinHostPort rather than naively
+// concatenating "host:port" (which produces an unparsable URL authority for
+// addresses like "fe80::1").
+func TestGetSummaryIPv6Host(t *testing.T) {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available in this environment: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats/summary/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"node":{"nodeName":"test-node"}}`))
+	})
+	server := httptest.Server{Listener: listener, Config: &http.Server{Handler: mux}}
+	server.Start()
+	defer server.Close()
+
+	_, portStr, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to split listener address: %v", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("failed to parse listener port: %v", err)
+	}
+
+	client, err := NewKubeletClient(KubeletClientConfig{
+		Port:           uint(port),
+		PortIsInsecure: true,
+	})
+	if err != nil {
+		t.Fatalf("NewKubeletClient() returned unexpected error: %v", err)
+	}
+
+	summary, err := client.GetSummary("::1")
+	if err != nil {
+		t.Fatalf("GetSummary() returned unexpected error for IPv6 host: %v", err)
+	}
+	if summary.Node.NodeName != "test-node" {
+		t.Errorf("summary.Node.NodeName = %q, want %q", summary.Node.NodeName, "test-node")
+	}
+}
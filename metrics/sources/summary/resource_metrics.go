@@ -0,0 +1,202 @@
+// Copyright 2018 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+)
+
+// Metric names exposed by the Kubelet's /metrics/resource endpoint that we
+// adapt into a stats.Summary.
+const (
+	metricNodeCPUUsageSecondsTotal       = "node_cpu_usage_seconds_total"
+	metricNodeMemoryWorkingSetBytes      = "node_memory_working_set_bytes"
+	metricContainerCPUUsageSecondsTotal  = "container_cpu_usage_seconds_total"
+	metricContainerMemoryWorkingSetBytes = "container_memory_working_set_bytes"
+
+	// metricScrapeError is a gauge, with no labels, that the Kubelet sets to
+	// 1 when it failed to collect the underlying cAdvisor/stats-provider
+	// data backing the samples above, and 0 otherwise. A stale or invalid
+	// read should not be silently reported as if it were a fresh sample.
+	metricScrapeError = "scrape_error"
+)
+
+// getResourceMetrics scrapes the Kubelet's /metrics/resource Prometheus
+// endpoint and adapts the samples into the same stats.Summary shape
+// GetSummary returns for /stats/summary, so downstream consumers don't need
+// to know which endpoint produced the data.
+func (kc *kubeletClient) getResourceMetrics(ctx context.Context, host string) (*stats.Summary, error) {
+	port, portIsInsecure := kc.resolveNodePort(ctx, host)
+	reqURL := url.URL{
+		Scheme: "https",
+		Host:   net.JoinHostPort(host, strconv.Itoa(int(port))),
+		Path:   "/metrics/resource",
+	}
+	if portIsInsecure {
+		reqURL.Scheme = "http"
+	}
+
+	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := kc.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, &ErrNotFound{req.URL.String()}
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed - %q", response.Status)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse /metrics/resource response from %q: %v", host, err)
+	}
+	return resourceMetricsToSummary(host, families)
+}
+
+// resourceMetricsToSummary adapts the Prometheus samples scraped from
+// /metrics/resource into the stats.Summary shape used by the rest of the
+// pipeline. It still returns the partially-built summary alongside an error
+// when the Kubelet's own scrape_error gauge flags the underlying collection
+// as having failed, so callers can decide whether stale data is usable.
+func resourceMetricsToSummary(nodeName string, families map[string]*dto.MetricFamily) (*stats.Summary, error) {
+	summary := &stats.Summary{Node: stats.NodeStats{NodeName: nodeName}}
+	podIndex := map[string]int{}
+
+	pod := func(namespace, name string) *stats.PodStats {
+		key := namespace + "/" + name
+		if idx, ok := podIndex[key]; ok {
+			return &summary.Pods[idx]
+		}
+		summary.Pods = append(summary.Pods, stats.PodStats{
+			PodRef: stats.PodReference{Name: name, Namespace: namespace},
+		})
+		podIndex[key] = len(summary.Pods) - 1
+		return &summary.Pods[len(summary.Pods)-1]
+	}
+
+	container := func(namespace, podName, name string) *stats.ContainerStats {
+		p := pod(namespace, podName)
+		for i := range p.Containers {
+			if p.Containers[i].Name == name {
+				return &p.Containers[i]
+			}
+		}
+		p.Containers = append(p.Containers, stats.ContainerStats{Name: name})
+		return &p.Containers[len(p.Containers)-1]
+	}
+
+	for _, metric := range families[metricNodeCPUUsageSecondsTotal].GetMetric() {
+		usageCoreNanoSeconds := uint64(metric.GetCounter().GetValue() * 1e9)
+		summary.Node.CPU = &stats.CPUStats{
+			Time:                 metricTimestamp(metric),
+			UsageCoreNanoSeconds: &usageCoreNanoSeconds,
+		}
+	}
+	for _, metric := range families[metricNodeMemoryWorkingSetBytes].GetMetric() {
+		workingSetBytes := uint64(metric.GetGauge().GetValue())
+		summary.Node.Memory = &stats.MemoryStats{
+			Time:            metricTimestamp(metric),
+			WorkingSetBytes: &workingSetBytes,
+		}
+	}
+	for _, metric := range families[metricContainerCPUUsageSecondsTotal].GetMetric() {
+		namespace, podName, containerName, ok := podContainerLabels(metric)
+		if !ok {
+			continue
+		}
+		usageCoreNanoSeconds := uint64(metric.GetCounter().GetValue() * 1e9)
+		container(namespace, podName, containerName).CPU = &stats.CPUStats{
+			Time:                 metricTimestamp(metric),
+			UsageCoreNanoSeconds: &usageCoreNanoSeconds,
+		}
+	}
+	for _, metric := range families[metricContainerMemoryWorkingSetBytes].GetMetric() {
+		namespace, podName, containerName, ok := podContainerLabels(metric)
+		if !ok {
+			continue
+		}
+		workingSetBytes := uint64(metric.GetGauge().GetValue())
+		container(namespace, podName, containerName).Memory = &stats.MemoryStats{
+			Time:            metricTimestamp(metric),
+			WorkingSetBytes: &workingSetBytes,
+		}
+	}
+
+	if kubeletScrapeFailed(families) {
+		return summary, fmt.Errorf("kubelet reported a scrape error collecting /metrics/resource for node %q", nodeName)
+	}
+	return summary, nil
+}
+
+// kubeletScrapeFailed reports whether the Kubelet flagged its own
+// /metrics/resource collection as failed via the scrape_error gauge.
+func kubeletScrapeFailed(families map[string]*dto.MetricFamily) bool {
+	for _, metric := range families[metricScrapeError].GetMetric() {
+		if metric.GetGauge().GetValue() != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// podContainerLabels extracts the namespace, pod, and container label
+// values metrics-resource samples are keyed by.
+func podContainerLabels(metric *dto.Metric) (namespace, pod, container string, ok bool) {
+	for _, label := range metric.GetLabel() {
+		switch label.GetName() {
+		case "namespace":
+			namespace = label.GetValue()
+		case "pod":
+			pod = label.GetValue()
+		case "container":
+			container = label.GetValue()
+		}
+	}
+	return namespace, pod, container, namespace != "" && pod != "" && container != ""
+}
+
+// metricTimestamp uses the sample's own timestamp when the Kubelet set one
+// (it does, to reflect when the underlying cAdvisor stat was collected),
+// falling back to the time the sample was scraped.
+func metricTimestamp(metric *dto.Metric) metav1.Time {
+	if ms := metric.GetTimestampMs(); ms != 0 {
+		return metav1.NewTime(time.Unix(0, ms*int64(time.Millisecond)))
+	}
+	return metav1.Now()
+}
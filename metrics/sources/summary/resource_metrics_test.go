@@ -0,0 +1,123 @@
+// Copyright 2018 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const resourceMetricsExposition = `
+# HELP node_cpu_usage_seconds_total Cumulative cpu time consumed by the node in core-seconds
+# TYPE node_cpu_usage_seconds_total counter
+node_cpu_usage_seconds_total 10.5 1000
+
+# HELP node_memory_working_set_bytes Current working set of the node in bytes
+# TYPE node_memory_working_set_bytes gauge
+node_memory_working_set_bytes 123456 1000
+
+# HELP container_cpu_usage_seconds_total Cumulative cpu time consumed by the container in core-seconds
+# TYPE container_cpu_usage_seconds_total counter
+container_cpu_usage_seconds_total{namespace="default",pod="pod-1",container="app"} 2.5 1000
+
+# HELP container_memory_working_set_bytes Current working set of the container in bytes
+# TYPE container_memory_working_set_bytes gauge
+container_memory_working_set_bytes{namespace="default",pod="pod-1",container="app"} 654321 1000
+
+# HELP scrape_error 1 if there was an error getting container metrics, 0 otherwise
+# TYPE scrape_error gauge
+scrape_error 0
+`
+
+func parseResourceMetrics(t *testing.T, text string) map[string]*dto.MetricFamily {
+	t.Helper()
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("failed to parse test exposition: %v", err)
+	}
+	return families
+}
+
+func TestResourceMetricsToSummary(t *testing.T) {
+	families := parseResourceMetrics(t, resourceMetricsExposition)
+
+	summary, err := resourceMetricsToSummary("node-1", families)
+	if err != nil {
+		t.Fatalf("resourceMetricsToSummary() returned unexpected error: %v", err)
+	}
+
+	if summary.Node.NodeName != "node-1" {
+		t.Errorf("summary.Node.NodeName = %q, want %q", summary.Node.NodeName, "node-1")
+	}
+	if summary.Node.CPU == nil || summary.Node.CPU.UsageCoreNanoSeconds == nil {
+		t.Fatalf("summary.Node.CPU.UsageCoreNanoSeconds not set")
+	}
+	if want := uint64(10.5 * 1e9); *summary.Node.CPU.UsageCoreNanoSeconds != want {
+		t.Errorf("summary.Node.CPU.UsageCoreNanoSeconds = %d, want %d", *summary.Node.CPU.UsageCoreNanoSeconds, want)
+	}
+	if summary.Node.Memory == nil || summary.Node.Memory.WorkingSetBytes == nil {
+		t.Fatalf("summary.Node.Memory.WorkingSetBytes not set")
+	}
+	if want := uint64(123456); *summary.Node.Memory.WorkingSetBytes != want {
+		t.Errorf("summary.Node.Memory.WorkingSetBytes = %d, want %d", *summary.Node.Memory.WorkingSetBytes, want)
+	}
+
+	if len(summary.Pods) != 1 {
+		t.Fatalf("len(summary.Pods) = %d, want 1", len(summary.Pods))
+	}
+	pod := summary.Pods[0]
+	if pod.PodRef.Namespace != "default" || pod.PodRef.Name != "pod-1" {
+		t.Errorf("pod.PodRef = %+v, want namespace=default name=pod-1", pod.PodRef)
+	}
+	if len(pod.Containers) != 1 {
+		t.Fatalf("len(pod.Containers) = %d, want 1", len(pod.Containers))
+	}
+	container := pod.Containers[0]
+	if container.Name != "app" {
+		t.Errorf("container.Name = %q, want %q", container.Name, "app")
+	}
+	if container.CPU == nil || container.CPU.UsageCoreNanoSeconds == nil {
+		t.Fatalf("container.CPU.UsageCoreNanoSeconds not set")
+	}
+	if want := uint64(2.5 * 1e9); *container.CPU.UsageCoreNanoSeconds != want {
+		t.Errorf("container.CPU.UsageCoreNanoSeconds = %d, want %d", *container.CPU.UsageCoreNanoSeconds, want)
+	}
+	if container.Memory == nil || container.Memory.WorkingSetBytes == nil {
+		t.Fatalf("container.Memory.WorkingSetBytes not set")
+	}
+	if want := uint64(654321); *container.Memory.WorkingSetBytes != want {
+		t.Errorf("container.Memory.WorkingSetBytes = %d, want %d", *container.Memory.WorkingSetBytes, want)
+	}
+}
+
+func TestResourceMetricsToSummaryScrapeError(t *testing.T) {
+	text := strings.Replace(resourceMetricsExposition, "scrape_error 0", "scrape_error 1", 1)
+	families := parseResourceMetrics(t, text)
+
+	summary, err := resourceMetricsToSummary("node-1", families)
+	if err == nil {
+		t.Fatalf("resourceMetricsToSummary() = nil error, want an error when scrape_error=1")
+	}
+	if summary == nil {
+		t.Fatalf("resourceMetricsToSummary() returned a nil summary alongside the scrape error, want the partially-built summary")
+	}
+	if summary.Node.NodeName != "node-1" {
+		t.Errorf("summary.Node.NodeName = %q, want %q", summary.Node.NodeName, "node-1")
+	}
+}